@@ -1,22 +1,20 @@
 package breaker
 
 import (
+	"context"
 	"errors"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
 type State int
 
-type StateService int
-
 const (
-	STATE_CLOSE         State        = 1
-	STATE_HALF_OPEN     State        = 2
-	STATE_OPEN          State        = 3
-	SERVICE_AVAILABLE   StateService = 1
-	SERVICE_UNAVAILABLE StateService = 0
+	STATE_CLOSE     State = 1
+	STATE_HALF_OPEN State = 2
+	STATE_OPEN      State = 3
 )
 
 type ResponseCommand struct {
@@ -27,23 +25,27 @@ type ResponseCommand struct {
 type HandleFunc func() ResponseCommand
 
 type CounterResult struct {
-	TotalRequests uint32
-	TotalSucceses uint32
-	TotalFailures uint32
-	TotalRejects  uint32
+	TotalRequests        uint32
+	TotalSucceses        uint32
+	TotalFailures        uint32
+	TotalRejects         uint32
+	TotalTimeouts        uint32
+	ConsecutiveSuccesses uint32
 }
 
 type Breaker struct {
-	options        OptionsConfig
-	counter        CounterResult
-	events         []chan BreakerEvent
-	state          State
-	consecFailures uint32
-}
-
-type BreakerEvent struct {
-	Code    StateService
-	Message string
+	options         OptionsConfig
+	counter         CounterResult
+	state           atomic.Int32
+	consecFailures  uint32
+	consecSuccesses uint32
+	generation      uint64
+	window          *slidingWindow
+	clock           Clock
+
+	eventsMu    sync.Mutex
+	subscribers []*subscription
+	ingress     chan BreakerEvent
 }
 
 type OptionsConfig struct {
@@ -52,6 +54,44 @@ type OptionsConfig struct {
 	LimitFailure uint32
 	MaxRequests  uint32
 	NameService  string
+
+	// WindowDuration and BucketCount configure the rolling failure
+	// window: outcomes are tallied into BucketCount buckets covering
+	// WindowDuration in total, and the window slides forward bucket by
+	// bucket as time passes. Leave either at zero to fall back to the
+	// consecutive-failure mode driven by LimitFailure.
+	WindowDuration time.Duration
+	BucketCount    uint32
+
+	// FailureRatio and MinRequests decide when the window trips: at
+	// least MinRequests must have been observed in the window, and at
+	// least FailureRatio of them must have failed. Left at zero once a
+	// window is configured, they default to 0.5 and 5 respectively
+	// rather than tripping on the very first failure.
+	FailureRatio float64
+	MinRequests  uint32
+
+	// ConsecutiveSuccesses is the number of back-to-back successes
+	// required in STATE_HALF_OPEN before the breaker closes. Defaults
+	// to 1, i.e. a single probe succeeding is enough to close.
+	ConsecutiveSuccesses uint32
+
+	// Clock lets callers replace the breaker's notion of time, e.g.
+	// with clocktest.FakeClock in tests. Defaults to the real clock.
+	Clock Clock
+
+	// StateChangeHook, when set, is called synchronously on every
+	// actual state transition, in addition to the BreakerEvent
+	// published to Subcriber's channels. Useful for pushing to logs or
+	// OpenTelemetry without maintaining a subscription.
+	StateChangeHook func(from, to State)
+
+	// DurationObserver, when set, is called with the duration of every
+	// Execute/ExecuteContext call that the breaker let through, measured
+	// on the breaker's Clock, once its handler returns or its context is
+	// done, whichever comes first. breaker/metrics uses this to feed a
+	// histogram.
+	DurationObserver func(d time.Duration)
 }
 
 func (cb *Breaker) ErrorToManyRequest() error {
@@ -66,6 +106,11 @@ func (cb *Breaker) ErrorServiceUnavailable() error {
 	return errors.New("Service is unavailable")
 }
 
+// Name returns the breaker's configured NameService.
+func (cb *Breaker) Name() string {
+	return cb.options.NameService
+}
+
 // create new instance breaker
 func NewBreaker(options *OptionsConfig) *Breaker {
 
@@ -93,44 +138,120 @@ func NewBreaker(options *OptionsConfig) *Breaker {
 		options.NameService = strconv.Itoa(int(time.Now().UnixNano()))
 	}
 
-	return &Breaker{options: *options, counter: CounterResult{}, state: STATE_CLOSE, consecFailures: 0}
-}
+	if options.ConsecutiveSuccesses == 0 {
+		options.ConsecutiveSuccesses = 1
+	}
 
-// send event to other service
-func (cb *Breaker) SendEvent(event *BreakerEvent) {
+	if options.Clock == nil {
+		options.Clock = newRealClock()
+	}
 
-	for _, reader := range cb.events {
-		reader <- *event
+	if options.WindowDuration > 0 && options.BucketCount > 0 {
+		// FailureRatio/MinRequests left at zero make Failure()'s trip
+		// check true on the very first failure (TotalFailures >=
+		// 0*TotalRequests and TotalRequests >= 0) - the exact
+		// hair-trigger behavior a window is meant to avoid - so default
+		// them the same way every other option above is defaulted.
+		if options.FailureRatio == 0 {
+			options.FailureRatio = 0.5
+		}
+		if options.MinRequests == 0 {
+			options.MinRequests = 5
+		}
 	}
-}
 
-// other services subcriber
-func (cb *Breaker) Subcriber() <-chan BreakerEvent {
+	cb := &Breaker{
+		options:        *options,
+		counter:        CounterResult{},
+		consecFailures: 0,
+		clock:          options.Clock,
+		ingress:        make(chan BreakerEvent, eventIngressBuffer),
+	}
+	cb.state.Store(int32(STATE_CLOSE))
+
+	if options.WindowDuration > 0 && options.BucketCount > 0 {
+		cb.window = newSlidingWindow(options.WindowDuration, options.BucketCount, cb.clock.Now())
+	}
 
-	evenReader := make(chan BreakerEvent)
-	outputChannel := make(chan BreakerEvent, 100)
+	go cb.dispatchEvents()
 
-	go func() {
-		for event := range evenReader {
-			select {
-			case outputChannel <- event:
-			default:
-				<-outputChannel
-				outputChannel <- event
-			}
+	return cb
+}
+
+// Counts returns a snapshot of the aggregated counters. When a rolling
+// window is configured TotalRequests/TotalSucceses/TotalFailures reflect
+// only that window; otherwise they reflect the lifetime totals.
+// TotalRejects and TotalTimeouts are always lifetime totals.
+func (cb *Breaker) Counts() CounterResult {
+	var result CounterResult
+	if cb.window != nil {
+		result = cb.window.snapshot(cb.clock.Now())
+	} else {
+		result = CounterResult{
+			TotalRequests: atomic.LoadUint32(&cb.counter.TotalRequests),
+			TotalSucceses: atomic.LoadUint32(&cb.counter.TotalSucceses),
+			TotalFailures: atomic.LoadUint32(&cb.counter.TotalFailures),
 		}
-	}()
+	}
+	result.ConsecutiveSuccesses = atomic.LoadUint32(&cb.consecSuccesses)
+	result.TotalRejects = atomic.LoadUint32(&cb.counter.TotalRejects)
+	result.TotalTimeouts = atomic.LoadUint32(&cb.counter.TotalTimeouts)
+	return result
+}
+
+// LifetimeCounts returns a snapshot of the breaker's monotonically
+// increasing lifetime counters. Unlike Counts, it is unaffected by a
+// configured rolling window: TotalRequests/TotalSucceses/TotalFailures
+// here only ever grow, whereas Counts reflects the window snapshot used
+// for trip decisions and can decrease as old buckets age out. Exporters
+// like breaker/metrics that feed a Prometheus counter need this one -
+// a non-monotonic value breaks the counter contract and corrupts any
+// rate()/increase() query against it.
+func (cb *Breaker) LifetimeCounts() CounterResult {
+	return CounterResult{
+		TotalRequests:        atomic.LoadUint32(&cb.counter.TotalRequests),
+		TotalSucceses:        atomic.LoadUint32(&cb.counter.TotalSucceses),
+		TotalFailures:        atomic.LoadUint32(&cb.counter.TotalFailures),
+		TotalRejects:         atomic.LoadUint32(&cb.counter.TotalRejects),
+		TotalTimeouts:        atomic.LoadUint32(&cb.counter.TotalTimeouts),
+		ConsecutiveSuccesses: atomic.LoadUint32(&cb.consecSuccesses),
+	}
+}
 
-	cb.events = append(cb.events, evenReader)
-	return outputChannel
+// currentGeneration returns the breaker's current epoch token.
+func (cb *Breaker) currentGeneration() uint64 {
+	return atomic.LoadUint64(&cb.generation)
 }
 
+// nextGeneration advances the epoch token, invalidating any in-flight
+// request that captured an earlier generation.
+func (cb *Breaker) nextGeneration() {
+	atomic.AddUint64(&cb.generation, 1)
+}
+
+// SetState transitions the breaker to newState. A no-op call (newState
+// equal to the current state) does nothing further: it must not publish
+// a BreakerEvent or advance the generation, or two racing callers
+// observing the same pre-transition state (e.g. two concurrent
+// Failure() calls in STATE_HALF_OPEN) would bump the generation twice
+// for a single real transition, discarding the outcome of any request
+// that started legitimately after the first bump.
 func (cb *Breaker) SetState(newState State) {
-	cb.state = newState
+	previous := State(cb.state.Swap(int32(newState)))
+	if previous == newState {
+		return
+	}
+
+	cb.nextGeneration()
+	cb.publish(BreakerEvent{From: previous, To: newState, At: cb.clock.Now(), Counts: cb.Counts()})
+
+	if cb.options.StateChangeHook != nil {
+		cb.options.StateChangeHook(previous, newState)
+	}
 }
 
 func (cb *Breaker) GetState() State {
-	return cb.state
+	return State(cb.state.Load())
 }
 
 // check current state is OPEN
@@ -165,11 +286,20 @@ func (cb *Breaker) Reject() {
 // invoke is successful
 func (cb *Breaker) Success() {
 	atomic.AddUint32(&cb.counter.TotalSucceses, 1)
-	if cb.IsHalfOpen() {
+	if cb.window != nil {
+		cb.window.record(cb.clock.Now(), true)
+	}
 
-		// change state HALF_OPEN --> CLOSE
-		cb.SetState(STATE_CLOSE)
-		atomic.StoreUint32(&cb.consecFailures, 0)
+	if cb.IsHalfOpen() {
+		consecSuccesses := atomic.AddUint32(&cb.consecSuccesses, 1)
+		if consecSuccesses >= cb.options.ConsecutiveSuccesses {
+			// change state HALF_OPEN --> CLOSE
+			cb.SetState(STATE_CLOSE)
+			atomic.StoreUint32(&cb.consecFailures, 0)
+			atomic.StoreUint32(&cb.consecSuccesses, 0)
+		}
+	} else {
+		atomic.StoreUint32(&cb.consecSuccesses, 0)
 	}
 }
 
@@ -177,7 +307,23 @@ func (cb *Breaker) Success() {
 func (cb *Breaker) Failure() {
 
 	atomic.AddUint32(&cb.counter.TotalFailures, 1)
+	if cb.window != nil {
+		cb.window.record(cb.clock.Now(), false)
+	}
 	if cb.IsClose() {
+
+		// with a window configured, trip on the failure ratio across
+		// the window instead of a raw consecutive-failure count
+		if cb.window != nil {
+			counts := cb.window.snapshot(cb.clock.Now())
+			if counts.TotalRequests >= cb.options.MinRequests && float64(counts.TotalFailures) >= cb.options.FailureRatio*float64(counts.TotalRequests) {
+				// change state CLOSE --> OPEN
+				cb.SetState(STATE_OPEN)
+				cb.StartTimerChangeState()
+			}
+			return
+		}
+
 		atomic.AddUint32(&cb.consecFailures, 1)
 		consecFailures := atomic.LoadUint32(&cb.consecFailures)
 
@@ -185,21 +331,14 @@ func (cb *Breaker) Failure() {
 			// change state CLOSE --> OPEN
 			cb.SetState(STATE_OPEN)
 			cb.StartTimerChangeState()
-
-			// send event service is unavailable to other proces
-			event := &BreakerEvent{Code: SERVICE_UNAVAILABLE, Message: "Service " + cb.options.NameService + " is unavailable"}
-			cb.SendEvent(event)
 		}
 	}
 	if cb.IsHalfOpen() {
 
 		// change state HALF_OPEN --> OPEN
 		cb.SetState(STATE_OPEN)
+		atomic.StoreUint32(&cb.consecSuccesses, 0)
 		cb.StartTimerChangeState()
-
-		// send event service is unavailable to other proces
-		event := &BreakerEvent{Code: SERVICE_UNAVAILABLE, Message: "Service " + cb.options.NameService + " is unavailable"}
-		cb.SendEvent(event)
 	}
 }
 
@@ -207,64 +346,106 @@ func (cb *Breaker) Failure() {
 func (cb *Breaker) StartTimerChangeState() {
 
 	go func() {
-		<-time.NewTimer(cb.options.TimeoutState).C
+		<-cb.clock.NewTimer(cb.options.TimeoutState).C()
 
 		if cb.GetState() == STATE_OPEN {
-			// set new state for service
+			// change state OPEN --> HALF_OPEN
 			cb.SetState(STATE_HALF_OPEN)
-
-			// send event service is unavailable to other proces
-			event := &BreakerEvent{Code: SERVICE_AVAILABLE, Message: "Service " + cb.options.NameService + " is available"}
-			cb.SendEvent(event)
 		}
 	}()
 }
 
-// execute handle
-func (cb *Breaker) Execute(handle func() ResponseCommand, timeout time.Duration) ResponseCommand {
-
-	// add request
+// beforeRequest records a new request attempt and returns the
+// generation token in effect at the time of the call. Callers must
+// thread this token through to afterRequest so that a result arriving
+// after the breaker has since moved to a new generation is discarded
+// instead of being applied to the wrong counters.
+func (cb *Breaker) beforeRequest() (uint64, error) {
 	atomic.AddUint32(&cb.counter.TotalRequests, 1)
-	response := ResponseCommand{}
 
-	// Reject all invoke when current state is OPEN
 	if cb.IsOpen() {
 		cb.Reject()
-		response.Error = cb.ErrorServiceUnavailable()
-		response.Data = nil
-		return response
+		return cb.currentGeneration(), cb.ErrorServiceUnavailable()
 	}
 
-	// run handle immediate and execute time is unlimited
-	if timeout == 0 {
-		response = handle()
-	} else {
+	return cb.currentGeneration(), nil
+}
 
-		// run handle with time execute is limited
-		c := make(chan ResponseCommand, 1)
-		go func() {
-			c <- handle()
-		}()
-
-		select {
-		case r := <-c:
-			response = r
-			close(c)
-		case <-time.NewTimer(timeout).C:
-			response.Error = cb.ErrorTimeoutExecute()
-			response.Data = nil
-		}
+// afterRequest applies the outcome of a call that started at
+// generation. If the breaker has since moved on to a new generation
+// (a state transition happened while the call was in flight) the
+// outcome is discarded rather than applied.
+func (cb *Breaker) afterRequest(generation uint64, err error) {
+	if generation != cb.currentGeneration() {
+		return
 	}
 
-	// check for any errors
-	if response.Error != nil {
+	if err != nil {
 		cb.Failure()
 	} else {
 		cb.Success()
 	}
+}
+
+// ExecuteContext runs handle guarded by the breaker, honoring ctx's
+// deadline and cancellation instead of a fixed timeout. handle always
+// runs to completion in its own goroutine so it is never left blocked
+// trying to hand back a result nobody is waiting for; if ctx is done
+// first, ExecuteContext returns immediately with ErrorTimeoutExecute,
+// which is counted as a timeout and handed to afterRequest as a
+// failure, and the late result (if
+// any) is left unread in the buffered channel and never applied to the
+// counters.
+func (cb *Breaker) ExecuteContext(ctx context.Context, handle func(context.Context) ResponseCommand) ResponseCommand {
+
+	generation, err := cb.beforeRequest()
+	if err != nil {
+		return ResponseCommand{Error: err}
+	}
+
+	start := cb.clock.Now()
+
+	c := make(chan ResponseCommand, 1)
+	go func() {
+		c <- handle(ctx)
+	}()
+
+	var response ResponseCommand
+	select {
+	case response = <-c:
+	case <-ctx.Done():
+		atomic.AddUint32(&cb.counter.TotalTimeouts, 1)
+		response = ResponseCommand{Error: cb.ErrorTimeoutExecute()}
+	}
+
+	if cb.options.DurationObserver != nil {
+		cb.options.DurationObserver(cb.clock.Now().Sub(start))
+	}
+
+	cb.afterRequest(generation, response.Error)
 	return response
 }
 
+// Execute is a thin wrapper over ExecuteContext for callers that only
+// need a fixed timeout and don't need the handler to observe context
+// cancellation. A zero timeout means the handler is given no deadline.
+// The timeout is driven by the breaker's Clock, so it can be advanced
+// deterministically under clocktest.FakeClock.
+func (cb *Breaker) Execute(handle func() ResponseCommand, timeout time.Duration) ResponseCommand {
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		timer := cb.clock.AfterFunc(timeout, cancel)
+		defer timer.Stop()
+	}
+
+	return cb.ExecuteContext(ctx, func(context.Context) ResponseCommand {
+		return handle()
+	})
+}
+
 func (s State) toString() string {
 	switch s {
 	case STATE_CLOSE:
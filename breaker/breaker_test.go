@@ -0,0 +1,202 @@
+package breaker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"circuit-breaker-go/breaker"
+	"circuit-breaker-go/breaker/clocktest"
+)
+
+// TestSuccessFailureConcurrent drives Success/Failure from many
+// goroutines at once with a window configured, so `go test -race` can
+// catch unsynchronized access to the sliding window's buckets.
+func TestSuccessFailureConcurrent(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{
+		WindowDuration: time.Minute,
+		BucketCount:    10,
+		FailureRatio:   0.5,
+		MinRequests:    1000000, // high enough that this run never trips
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if (i+j)%2 == 0 {
+					cb.Success()
+				} else {
+					cb.Failure()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	counts := cb.Counts()
+	if counts.TotalRequests != 5000 {
+		t.Fatalf("TotalRequests = %d, want 5000", counts.TotalRequests)
+	}
+	if counts.TotalSucceses+counts.TotalFailures != counts.TotalRequests {
+		t.Fatalf("successes (%d) + failures (%d) != requests (%d)", counts.TotalSucceses, counts.TotalFailures, counts.TotalRequests)
+	}
+}
+
+// TestWindowDefaultsFailureRatioAndMinRequests verifies that enabling
+// the window with only WindowDuration/BucketCount set doesn't trip on
+// the very first failure: FailureRatio and MinRequests must default to
+// something non-zero, or Failure()'s trip check (TotalFailures >=
+// 0*TotalRequests and TotalRequests >= 0) is true immediately.
+func TestWindowDefaultsFailureRatioAndMinRequests(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{
+		WindowDuration: time.Minute,
+		BucketCount:    10,
+	})
+
+	cb.Failure()
+	if cb.IsOpen() {
+		t.Fatalf("state = %v after a single failure, want CLOSE; FailureRatio/MinRequests must default to non-zero", cb.GetState())
+	}
+}
+
+// TestCountsConcurrentWithSuccess exercises Counts() concurrently with
+// Success() on the non-window path, to catch the plain struct copy of
+// atomically-mutated counters that go test -race flags.
+func TestCountsConcurrentWithSuccess(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{LimitFailure: 1000000})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			cb.Success()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = cb.Counts()
+		}
+	}()
+	wg.Wait()
+
+	if got := cb.Counts().TotalSucceses; got != 1000 {
+		t.Fatalf("TotalSucceses = %d, want 1000", got)
+	}
+}
+
+// TestSetStateConcurrentWithGetState drives SetState from one goroutine
+// and GetState (via IsOpen) from others at the same time, the way a
+// production caller polling breaker state from a different goroutine
+// than the one driving timeouts would, to catch unsynchronized access
+// to the state field.
+func TestSetStateConcurrentWithGetState(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{LimitFailure: 1000000})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			if i%2 == 0 {
+				cb.SetState(breaker.STATE_OPEN)
+			} else {
+				cb.SetState(breaker.STATE_CLOSE)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = cb.IsOpen()
+			_ = cb.GetState()
+		}
+	}()
+	wg.Wait()
+}
+
+// TestStateTransitionsWithFakeClock drives the breaker from CLOSE to
+// OPEN to HALF_OPEN to CLOSE using clocktest.FakeClock, so the
+// TimeoutState wait never depends on a real time.Sleep.
+func TestStateTransitionsWithFakeClock(t *testing.T) {
+	clock := clocktest.NewFakeClock(time.Unix(0, 0))
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{
+		LimitFailure:         2,
+		TimeoutState:         time.Second,
+		ConsecutiveSuccesses: 1,
+		Clock:                clock,
+	})
+
+	for i := 0; i < 3; i++ {
+		cb.Failure()
+	}
+	if !cb.IsOpen() {
+		t.Fatalf("state = %v, want OPEN after exceeding LimitFailure", cb.GetState())
+	}
+
+	// StartTimerChangeState registers its timer on its own goroutine, so
+	// give it a chance to run before advancing the clock past it.
+	waitFor(t, func() bool {
+		clock.Advance(time.Second)
+		return cb.IsHalfOpen()
+	})
+
+	cb.Success()
+	if !cb.IsClose() {
+		t.Fatalf("state = %v, want CLOSE after a success in HALF_OPEN", cb.GetState())
+	}
+}
+
+// TestExecuteContextDiscardsStaleSuccessAfterTrip verifies the race the
+// generation token exists to prevent: a handler still in flight when
+// the breaker trips from another goroutine must not be allowed to
+// re-close the breaker or count towards its totals once it finally
+// returns.
+func TestExecuteContextDiscardsStaleSuccessAfterTrip(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{LimitFailure: 1000000})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan breaker.ResponseCommand, 1)
+
+	go func() {
+		done <- cb.ExecuteContext(context.Background(), func(ctx context.Context) breaker.ResponseCommand {
+			close(started)
+			<-release
+			return breaker.ResponseCommand{}
+		})
+	}()
+
+	<-started
+	cb.SetState(breaker.STATE_OPEN) // trips while the handler is still in flight
+	close(release)
+	<-done
+
+	if !cb.IsOpen() {
+		t.Fatalf("state = %v, want OPEN; the in-flight handler's late success re-closed the breaker", cb.GetState())
+	}
+	if counts := cb.Counts(); counts.TotalSucceses != 0 {
+		t.Fatalf("TotalSucceses = %d, want 0; the in-flight handler's late success was applied instead of discarded", counts.TotalSucceses)
+	}
+}
+
+// waitFor polls until cond is true or a short deadline elapses; it
+// exists because StartTimerChangeState fires the OPEN->HALF_OPEN
+// transition on its own goroutine, even when the wait itself is driven
+// by a FakeClock.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("condition never became true")
+}
@@ -0,0 +1,50 @@
+package breaker
+
+import "time"
+
+// Timer is the subset of time.Timer that Clock implementations need to
+// provide.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Clock abstracts time so the breaker's internal timers can be driven
+// deterministically in tests instead of relying on real wall-clock
+// sleeps. OptionsConfig.Clock defaults to a real implementation backed
+// by the time package.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+type realClock struct{}
+
+func newRealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{timer: time.NewTimer(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return &realTimer{timer: time.AfterFunc(d, f)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t *realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t *realTimer) Stop() bool {
+	return t.timer.Stop()
+}
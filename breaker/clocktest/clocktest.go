@@ -0,0 +1,122 @@
+// Package clocktest provides a breaker.Clock implementation for
+// deterministic tests: time only moves forward when Advance is called.
+package clocktest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"circuit-breaker-go/breaker"
+)
+
+// FakeClock is a breaker.Clock whose time only moves forward when
+// Advance is called, firing any timers whose deadline has been reached
+// synchronously and in deadline order.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock starting at start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the clock's current, fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer returns a Timer whose channel fires once Advance moves the
+// clock past d from now.
+func (c *FakeClock) NewTimer(d time.Duration) breaker.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// AfterFunc schedules f to run once Advance moves the clock past d
+// from now. f runs synchronously, on the goroutine calling Advance.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) breaker.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), fn: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and fires every pending timer
+// whose deadline has now been reached, in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+
+	var due []*fakeTimer
+	var remaining []*fakeTimer
+	for _, t := range c.timers {
+		if !t.deadline.After(now) {
+			due = append(due, t)
+		} else {
+			remaining = append(remaining, t)
+		}
+	}
+	c.timers = remaining
+	c.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].deadline.Before(due[j].deadline)
+	})
+
+	for _, t := range due {
+		t.fire(now)
+	}
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	ch       chan time.Time
+	fn       func()
+	stopped  bool
+	fired    bool
+}
+
+func (t *fakeTimer) fire(at time.Time) {
+	t.clock.mu.Lock()
+	alreadyStopped := t.stopped
+	t.fired = true
+	t.clock.mu.Unlock()
+
+	if alreadyStopped {
+		return
+	}
+	if t.fn != nil {
+		t.fn()
+		return
+	}
+	t.ch <- at
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	if t.fired || t.stopped {
+		return false
+	}
+	t.stopped = true
+	return true
+}
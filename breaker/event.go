@@ -0,0 +1,133 @@
+package breaker
+
+import "time"
+
+// eventIngressBuffer bounds how many published events can be queued
+// ahead of the dispatcher goroutine before the oldest is dropped.
+const eventIngressBuffer = 256
+
+// eventSubscriberBuffer bounds how many events a single subscriber can
+// lag behind before the oldest is dropped in its favor; a slow
+// subscriber can never stall the breaker.
+const eventSubscriberBuffer = 100
+
+// BreakerEvent describes a single state transition.
+type BreakerEvent struct {
+	From   State
+	To     State
+	At     time.Time
+	Counts CounterResult
+}
+
+// subscription pairs a subscriber's channel with a closed flag that
+// dispatchEvents' send path and Unsubscribe both check/set under
+// eventsMu, so the channel can be closed (letting a `for range ch`
+// consumer terminate) without ever racing a pending send.
+type subscription struct {
+	ch     chan BreakerEvent
+	closed bool
+}
+
+// publish hands event to the dispatcher goroutine, dropping the oldest
+// queued event rather than blocking the caller if the ingress is full.
+func (cb *Breaker) publish(event BreakerEvent) {
+	select {
+	case cb.ingress <- event:
+		return
+	default:
+	}
+
+	select {
+	case <-cb.ingress:
+	default:
+	}
+
+	select {
+	case cb.ingress <- event:
+	default:
+	}
+}
+
+// dispatchEvents is the breaker's single dispatcher goroutine: it reads
+// published events and fans them out to every subscriber with a
+// non-blocking send, so a slow subscriber can never stall the caller
+// that triggered the transition.
+func (cb *Breaker) dispatchEvents() {
+	for event := range cb.ingress {
+		cb.eventsMu.Lock()
+		subs := make([]*subscription, len(cb.subscribers))
+		copy(subs, cb.subscribers)
+		cb.eventsMu.Unlock()
+
+		for _, sub := range subs {
+			cb.sendToSubscriber(sub, event)
+		}
+	}
+}
+
+// sendToSubscriber delivers event to sub, dropping its oldest queued
+// event to make room if it's lagging. It holds eventsMu for the whole
+// attempt so it can never race Unsubscribe closing sub.ch out from
+// under it.
+func (cb *Breaker) sendToSubscriber(sub *subscription, event BreakerEvent) {
+	cb.eventsMu.Lock()
+	defer cb.eventsMu.Unlock()
+
+	if sub.closed {
+		return
+	}
+
+	select {
+	case sub.ch <- event:
+		return
+	default:
+	}
+
+	// subscriber is lagging: drop its oldest queued event to make room
+	// rather than blocking the dispatcher
+	select {
+	case <-sub.ch:
+	default:
+	}
+	select {
+	case sub.ch <- event:
+	default:
+	}
+}
+
+// Subcriber registers a new subscriber and returns the channel it will
+// receive BreakerEvents on. Safe for concurrent use.
+func (cb *Breaker) Subcriber() <-chan BreakerEvent {
+	sub := &subscription{ch: make(chan BreakerEvent, eventSubscriberBuffer)}
+
+	cb.eventsMu.Lock()
+	cb.subscribers = append(cb.subscribers, sub)
+	cb.eventsMu.Unlock()
+
+	return sub.ch
+}
+
+// Unsubscribe removes a subscriber previously returned by Subcriber and
+// closes its channel, so a consumer doing `for event := range ch` (the
+// pattern client/main.go uses) terminates instead of leaking. Safe for
+// concurrent use.
+//
+// Closing is safe here, unlike a bare close(sub.ch) would be: it marks
+// the subscription closed and closes the channel under the same
+// eventsMu that sendToSubscriber holds for its entire send attempt, so
+// a dispatch already in progress for this subscriber either finishes
+// its send before Unsubscribe acquires the lock, or observes closed
+// and skips the send - never both at once.
+func (cb *Breaker) Unsubscribe(reader <-chan BreakerEvent) {
+	cb.eventsMu.Lock()
+	defer cb.eventsMu.Unlock()
+
+	for i, sub := range cb.subscribers {
+		if sub.ch == reader {
+			cb.subscribers = append(cb.subscribers[:i], cb.subscribers[i+1:]...)
+			sub.closed = true
+			close(sub.ch)
+			return
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package breaker_test
+
+import (
+	"sync"
+	"testing"
+
+	"circuit-breaker-go/breaker"
+)
+
+// TestUnsubscribeConcurrentWithDispatch subscribes and unsubscribes
+// concurrently with a steady stream of published events, so a dispatch
+// cycle that already snapshotted a subscriber's channel can still be
+// sending to it the moment Unsubscribe runs. It must not panic with
+// "send on closed channel".
+func TestUnsubscribeConcurrentWithDispatch(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{LimitFailure: 1000000})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			if i%2 == 0 {
+				cb.SetState(breaker.STATE_HALF_OPEN)
+			} else {
+				cb.SetState(breaker.STATE_CLOSE)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ch := cb.Subcriber()
+			go func() {
+				for range ch {
+				}
+			}()
+			cb.Unsubscribe(ch)
+		}()
+	}
+	wg.Wait()
+	<-done
+}
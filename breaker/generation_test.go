@@ -0,0 +1,31 @@
+package breaker
+
+import "testing"
+
+// TestSetStateNoOpDoesNotBumpGeneration verifies that calling SetState
+// with newState equal to the breaker's current state does not advance
+// the generation. Only a genuine transition may do that: two
+// concurrent Failure() calls both observing STATE_HALF_OPEN would both
+// call SetState(STATE_OPEN) - the first performing the real
+// HALF_OPEN->OPEN transition, the second a harmless-looking OPEN->OPEN
+// no-op. If the no-op also bumped the generation, any request still in
+// flight after the real transition would have its outcome silently
+// discarded by afterRequest instead of applied.
+func TestSetStateNoOpDoesNotBumpGeneration(t *testing.T) {
+	cb := NewBreaker(&OptionsConfig{LimitFailure: 1000000})
+	cb.SetState(STATE_HALF_OPEN)
+
+	before := cb.currentGeneration()
+
+	cb.SetState(STATE_OPEN) // the real transition
+	afterReal := cb.currentGeneration()
+	if afterReal != before+1 {
+		t.Fatalf("generation after a real transition = %d, want %d", afterReal, before+1)
+	}
+
+	cb.SetState(STATE_OPEN) // a second caller observing the same pre-transition state
+	afterNoop := cb.currentGeneration()
+	if afterNoop != afterReal {
+		t.Fatalf("generation changed from %d to %d due to a no-op SetState call", afterReal, afterNoop)
+	}
+}
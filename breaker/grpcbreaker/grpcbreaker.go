@@ -0,0 +1,100 @@
+// Package grpcbreaker wraps a breaker.Breaker as grpc client
+// interceptors.
+package grpcbreaker
+
+import (
+	"circuit-breaker-go/breaker"
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ShouldTrip decides whether a call error should be counted as a
+// breaker failure. The default treats codes.Unavailable and
+// codes.DeadlineExceeded as failures and ignores every other code.
+type ShouldTrip func(err error) bool
+
+func defaultShouldTrip(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor guarded
+// by cb. Calls are rejected with breaker.ErrorServiceUnavailable while
+// the breaker is open; otherwise the call runs under ctx directly and
+// its outcome is classified by shouldTrip (defaultShouldTrip when nil).
+func UnaryClientInterceptor(cb *breaker.Breaker, shouldTrip ShouldTrip) grpc.UnaryClientInterceptor {
+
+	if shouldTrip == nil {
+		shouldTrip = defaultShouldTrip
+	}
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		result := cb.ExecuteContext(ctx, func(ctx context.Context) breaker.ResponseCommand {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			cmd := breaker.ResponseCommand{Data: err}
+			if shouldTrip(err) {
+				cmd.Error = err
+			}
+			return cmd
+		})
+
+		// an Error with nil Data means the handle never ran, i.e. the
+		// breaker rejected the call or the call timed out
+		if result.Error != nil && result.Data == nil {
+			return result.Error
+		}
+		if err, _ := result.Data.(error); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// StreamClientInterceptor returns a grpc.StreamClientInterceptor
+// guarded by cb. Only stream establishment counts towards the breaker;
+// stream establishment is rejected with breaker.ErrorServiceUnavailable
+// while the breaker is open.
+func StreamClientInterceptor(cb *breaker.Breaker, shouldTrip ShouldTrip) grpc.StreamClientInterceptor {
+
+	if shouldTrip == nil {
+		shouldTrip = defaultShouldTrip
+	}
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+
+		var stream grpc.ClientStream
+		result := cb.ExecuteContext(ctx, func(ctx context.Context) breaker.ResponseCommand {
+			s, err := streamer(ctx, desc, cc, method, opts...)
+			stream = s
+			cmd := breaker.ResponseCommand{Data: err}
+			if shouldTrip(err) {
+				cmd.Error = err
+			}
+			return cmd
+		})
+
+		// an Error with nil Data means the handle never ran, i.e. the
+		// breaker rejected the call or the call timed out
+		if result.Error != nil && result.Data == nil {
+			return nil, result.Error
+		}
+		// streamer's own error, whether or not shouldTrip counted it
+		// against the breaker, must still reach the caller rather than
+		// being swallowed in favor of a nil stream
+		if err, _ := result.Data.(error); err != nil {
+			return nil, err
+		}
+		return stream, nil
+	}
+}
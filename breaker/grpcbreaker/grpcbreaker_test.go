@@ -0,0 +1,107 @@
+package grpcbreaker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"circuit-breaker-go/breaker"
+	"circuit-breaker-go/breaker/grpcbreaker"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeClientStream struct {
+	grpc.ClientStream
+}
+
+func TestStreamClientInterceptorSurfacesUntrippedError(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	wantErr := status.Error(codes.NotFound, "no such resource")
+
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, wantErr
+	}
+
+	interceptor := grpcbreaker.StreamClientInterceptor(cb, nil)
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "Service/Method", streamer)
+
+	if stream != nil {
+		t.Fatalf("stream = %v, want nil", stream)
+	}
+	if !errors.Is(err, wantErr) && status.Code(err) != codes.NotFound {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+}
+
+func TestStreamClientInterceptorPassesThroughSuccess(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	want := &fakeClientStream{}
+
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return want, nil
+	}
+
+	interceptor := grpcbreaker.StreamClientInterceptor(cb, nil)
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "Service/Method", streamer)
+
+	if err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+	if stream != want {
+		t.Fatalf("stream = %v, want %v", stream, want)
+	}
+}
+
+func TestStreamClientInterceptorRejectsWhileOpen(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{LimitFailure: 1})
+	for i := 0; i < 2; i++ {
+		cb.Failure()
+	}
+	if !cb.IsOpen() {
+		t.Fatalf("state = %v, want OPEN", cb.GetState())
+	}
+
+	called := false
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		called = true
+		return &fakeClientStream{}, nil
+	}
+
+	interceptor := grpcbreaker.StreamClientInterceptor(cb, nil)
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "Service/Method", streamer)
+
+	if called {
+		t.Fatal("streamer ran while the breaker was open")
+	}
+	if stream != nil {
+		t.Fatalf("stream = %v, want nil", stream)
+	}
+	// ErrorServiceUnavailable builds a new error value each call, so
+	// compare by message rather than identity.
+	if err == nil || err.Error() != cb.ErrorServiceUnavailable().Error() {
+		t.Fatalf("err = %v, want %v", err, cb.ErrorServiceUnavailable())
+	}
+}
+
+func TestUnaryClientInterceptorSurfacesUntrippedError(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	wantErr := status.Error(codes.InvalidArgument, "bad request")
+
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return wantErr
+	}
+
+	interceptor := grpcbreaker.UnaryClientInterceptor(cb, nil)
+	err := interceptor(context.Background(), "Service/Method", nil, nil, nil, invoker)
+
+	if err != wantErr {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+	// an untripped error must not count against the breaker
+	if counts := cb.Counts(); counts.TotalFailures != 0 {
+		t.Fatalf("TotalFailures = %d, want 0", counts.TotalFailures)
+	}
+}
@@ -0,0 +1,78 @@
+// Package httpbreaker wraps a breaker.Breaker as standard net/http
+// integration points: a RoundTripper for clients and a middleware for
+// servers.
+package httpbreaker
+
+import (
+	"circuit-breaker-go/breaker"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ShouldTrip decides, given the transport error (if any) and the
+// response it produced, whether the call should be counted as a
+// breaker failure. The default implementation counts any transport
+// error or non-2xx response as a failure; supply a custom ShouldTrip
+// to, for example, stop counting 4xx responses against the breaker.
+type ShouldTrip func(err error, resp *http.Response) bool
+
+func defaultShouldTrip(err error, resp *http.Response) bool {
+	if err != nil {
+		return true
+	}
+	return resp == nil || resp.StatusCode < 200 || resp.StatusCode >= 300
+}
+
+// RoundTripper wraps Next with Breaker, rejecting calls with
+// breaker.ErrorServiceUnavailable while the breaker is open.
+type RoundTripper struct {
+	Breaker    *breaker.Breaker
+	Next       http.RoundTripper
+	ShouldTrip ShouldTrip
+}
+
+// New returns a RoundTripper guarded by cb. next defaults to
+// http.DefaultTransport when nil.
+func New(cb *breaker.Breaker, next http.RoundTripper) *RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RoundTripper{Breaker: cb, Next: next}
+}
+
+func (rt *RoundTripper) shouldTrip() ShouldTrip {
+	if rt.ShouldTrip != nil {
+		return rt.ShouldTrip
+	}
+	return defaultShouldTrip
+}
+
+// RoundTrip executes req through Next, guarded by Breaker. req's
+// context governs cancellation directly, rather than a timeout derived
+// from it.
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	result := rt.Breaker.ExecuteContext(req.Context(), func(ctx context.Context) breaker.ResponseCommand {
+		resp, err := rt.Next.RoundTrip(req)
+		if err != nil {
+			if rt.shouldTrip()(err, nil) {
+				return breaker.ResponseCommand{Error: err}
+			}
+			// not counted against the breaker, but still returned
+			return breaker.ResponseCommand{Data: err}
+		}
+		if rt.shouldTrip()(nil, resp) {
+			return breaker.ResponseCommand{Data: resp, Error: fmt.Errorf("httpbreaker: unexpected status %d", resp.StatusCode)}
+		}
+		return breaker.ResponseCommand{Data: resp}
+	})
+
+	if resp, ok := result.Data.(*http.Response); ok {
+		return resp, nil
+	}
+	if err, ok := result.Data.(error); ok {
+		return nil, err
+	}
+	return nil, result.Error
+}
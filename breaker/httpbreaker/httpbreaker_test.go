@@ -0,0 +1,128 @@
+package httpbreaker_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"circuit-breaker-go/breaker"
+	"circuit-breaker-go/breaker/httpbreaker"
+)
+
+// roundTripFunc lets a plain function satisfy http.RoundTripper, so
+// tests can stub Next without spinning up a real server.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRoundTripperPassesThroughSuccess(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	rt := httpbreaker.New(cb, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if counts := cb.Counts(); counts.TotalFailures != 0 {
+		t.Fatalf("TotalFailures = %d, want 0", counts.TotalFailures)
+	}
+}
+
+func TestRoundTripperCountsNon2xxAsFailure(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	rt := httpbreaker.New(cb, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusInternalServerError}, nil
+	}))
+
+	resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil))
+	// the transport didn't fail, so RoundTrip's own contract (only
+	// return an error on a transport failure) holds...
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+	// ...while the breaker still counts it as a failure
+	if counts := cb.Counts(); counts.TotalFailures != 1 {
+		t.Fatalf("TotalFailures = %d, want 1", counts.TotalFailures)
+	}
+}
+
+func TestRoundTripperCountsTransportErrorAsFailure(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	wantErr := errors.New("connection refused")
+	rt := httpbreaker.New(cb, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, wantErr
+	}))
+
+	_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if counts := cb.Counts(); counts.TotalFailures != 1 {
+		t.Fatalf("TotalFailures = %d, want 1", counts.TotalFailures)
+	}
+}
+
+func TestRoundTripperShouldTripOptsOutOf4xx(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	rt := httpbreaker.New(cb, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound}, nil
+	}))
+	rt.ShouldTrip = func(err error, resp *http.Response) bool {
+		return err != nil || resp.StatusCode >= 500
+	}
+
+	resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if counts := cb.Counts(); counts.TotalFailures != 0 {
+		t.Fatalf("TotalFailures = %d, want 0; custom ShouldTrip opted 404 out of counting as a failure", counts.TotalFailures)
+	}
+}
+
+func TestRoundTripperRejectsWhileOpen(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{LimitFailure: 1})
+	for i := 0; i < 2; i++ {
+		cb.Failure()
+	}
+	if !cb.IsOpen() {
+		t.Fatalf("state = %v, want OPEN", cb.GetState())
+	}
+
+	called := false
+	rt := httpbreaker.New(cb, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil))
+	if called {
+		t.Fatal("Next ran while the breaker was open")
+	}
+	if err == nil || err.Error() != cb.ErrorServiceUnavailable().Error() {
+		t.Fatalf("err = %v, want %v", err, cb.ErrorServiceUnavailable())
+	}
+}
+
+func TestNewDefaultsNextToDefaultTransport(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	rt := httpbreaker.New(cb, nil)
+
+	if rt.Next != http.DefaultTransport {
+		t.Fatalf("Next = %v, want http.DefaultTransport", rt.Next)
+	}
+}
@@ -0,0 +1,61 @@
+package httpbreaker
+
+import (
+	"circuit-breaker-go/breaker"
+	"fmt"
+	"net/http"
+)
+
+// statusRecorder wraps an http.ResponseWriter to capture the status
+// code written by the downstream handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Middleware returns a func(http.Handler) http.Handler that guards the
+// wrapped handler with cb: requests are rejected with
+// breaker.ErrorServiceUnavailable while the breaker is open, and a
+// non-2xx response from the handler is fed back as a Failure.
+// shouldTrip may be nil, in which case the default non-2xx rule is used.
+//
+// Unlike RoundTripper, Middleware calls cb.Execute rather than
+// cb.ExecuteContext(r.Context(), ...): next always writes straight to
+// w, and racing that write against r.Context() being done (client
+// disconnect, deadline) would let the breaker give up and write its own
+// error to w while next is still writing to it concurrently. Execute
+// with no timeout waits for next to actually finish before touching w
+// again, so there is only ever one writer.
+func Middleware(cb *breaker.Breaker, shouldTrip ShouldTrip) func(http.Handler) http.Handler {
+
+	if shouldTrip == nil {
+		shouldTrip = defaultShouldTrip
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			result := cb.Execute(func() breaker.ResponseCommand {
+				next.ServeHTTP(recorder, r)
+				if shouldTrip(nil, &http.Response{StatusCode: recorder.status}) {
+					return breaker.ResponseCommand{Error: fmt.Errorf("httpbreaker: handler responded %d", recorder.status)}
+				}
+				return breaker.ResponseCommand{}
+			}, 0)
+
+			// the handler only runs when the breaker let the call
+			// through, so an error with an untouched status means the
+			// breaker rejected the call itself
+			if result.Error != nil && recorder.status == http.StatusOK {
+				http.Error(w, result.Error.Error(), http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
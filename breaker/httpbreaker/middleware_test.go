@@ -0,0 +1,76 @@
+package httpbreaker_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"circuit-breaker-go/breaker"
+	"circuit-breaker-go/breaker/httpbreaker"
+)
+
+func TestMiddlewarePassesThroughSuccess(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	handler := httpbreaker.Middleware(cb, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec.Body.String() != "ok" {
+		t.Fatalf("body = %q, want %q", rec.Body.String(), "ok")
+	}
+	if counts := cb.Counts(); counts.TotalFailures != 0 {
+		t.Fatalf("TotalFailures = %d, want 0", counts.TotalFailures)
+	}
+}
+
+func TestMiddlewareCountsNon2xxAsFailure(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{})
+	handler := httpbreaker.Middleware(cb, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// the handler's own response reaches the client unchanged...
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	// ...while the breaker still counts it as a failure
+	if counts := cb.Counts(); counts.TotalFailures != 1 {
+		t.Fatalf("TotalFailures = %d, want 1", counts.TotalFailures)
+	}
+}
+
+func TestMiddlewareRejectsWhileOpen(t *testing.T) {
+	cb := breaker.NewBreaker(&breaker.OptionsConfig{LimitFailure: 1})
+	for i := 0; i < 2; i++ {
+		cb.Failure()
+	}
+	if !cb.IsOpen() {
+		t.Fatalf("state = %v, want OPEN", cb.GetState())
+	}
+
+	called := false
+	handler := httpbreaker.Middleware(cb, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if called {
+		t.Fatal("handler ran while the breaker was open")
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
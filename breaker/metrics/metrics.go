@@ -0,0 +1,137 @@
+// Package metrics exposes one or more breaker.Breaker instances as a
+// prometheus.Collector.
+package metrics
+
+import (
+	"time"
+
+	"circuit-breaker-go/breaker"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	stateDesc = prometheus.NewDesc(
+		"circuit_breaker_state",
+		"Current breaker state (0=closed, 1=half_open, 2=open).",
+		[]string{"service"}, nil,
+	)
+	requestsDesc = prometheus.NewDesc(
+		"circuit_breaker_requests_total",
+		"Total requests seen by the breaker.",
+		[]string{"service"}, nil,
+	)
+	successesDesc = prometheus.NewDesc(
+		"circuit_breaker_successes_total",
+		"Total successful calls.",
+		[]string{"service"}, nil,
+	)
+	failuresDesc = prometheus.NewDesc(
+		"circuit_breaker_failures_total",
+		"Total failed calls.",
+		[]string{"service"}, nil,
+	)
+	rejectsDesc = prometheus.NewDesc(
+		"circuit_breaker_rejects_total",
+		"Total calls rejected while the breaker was open.",
+		[]string{"service"}, nil,
+	)
+	timeoutsDesc = prometheus.NewDesc(
+		"circuit_breaker_timeouts_total",
+		"Total calls that hit the breaker's timeout.",
+		[]string{"service"}, nil,
+	)
+)
+
+// stateValue maps a breaker.State to the value exposed on the state
+// gauge, independent of the breaker package's own enum values.
+func stateValue(s breaker.State) float64 {
+	switch s {
+	case breaker.STATE_CLOSE:
+		return 0
+	case breaker.STATE_HALF_OPEN:
+		return 1
+	case breaker.STATE_OPEN:
+		return 2
+	default:
+		return -1
+	}
+}
+
+// Collector is a prometheus.Collector wrapping one or more breakers,
+// each identified by its Name(). Register it with prometheus.Register
+// or prometheus.MustRegister.
+type Collector struct {
+	breakers map[string]*breaker.Breaker
+	duration *prometheus.HistogramVec
+}
+
+// New returns a Collector for breakers. Each breaker's Name() must be
+// unique among the set. Wire Observer(breaker.Name()) into that
+// breaker's OptionsConfig.DurationObserver at construction time to feed
+// the execution-duration histogram.
+func New(breakers ...*breaker.Breaker) *Collector {
+	c := &Collector{
+		breakers: make(map[string]*breaker.Breaker, len(breakers)),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "circuit_breaker_execute_duration_seconds",
+			Help: "Handler execution duration observed through Execute/ExecuteContext.",
+		}, []string{"service"}),
+	}
+
+	for _, cb := range breakers {
+		c.Add(cb)
+	}
+
+	return c
+}
+
+// Add registers cb with the collector. Since a breaker's Observer must
+// be wired in at construction time (via OptionsConfig.DurationObserver)
+// but the collector only learns the breaker's identity afterwards, the
+// usual sequence is:
+//
+//	c := metrics.New()
+//	cb := breaker.NewBreaker(&breaker.OptionsConfig{NameService: "svc", DurationObserver: c.Observer("svc")})
+//	c.Add(cb)
+func (c *Collector) Add(cb *breaker.Breaker) {
+	c.breakers[cb.Name()] = cb
+}
+
+// Observer returns a func(time.Duration) suitable for
+// OptionsConfig.DurationObserver that records into this Collector's
+// duration histogram under service.
+func (c *Collector) Observer(service string) func(time.Duration) {
+	return func(d time.Duration) {
+		c.duration.WithLabelValues(service).Observe(d.Seconds())
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- stateDesc
+	ch <- requestsDesc
+	ch <- successesDesc
+	ch <- failuresDesc
+	ch <- rejectsDesc
+	ch <- timeoutsDesc
+	c.duration.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for service, cb := range c.breakers {
+		// LifetimeCounts, not Counts: when the breaker has a rolling
+		// window configured, Counts reflects only that window and can
+		// decrease as old buckets age out, which would break the
+		// Prometheus counter contract below.
+		counts := cb.LifetimeCounts()
+
+		ch <- prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, stateValue(cb.GetState()), service)
+		ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.CounterValue, float64(counts.TotalRequests), service)
+		ch <- prometheus.MustNewConstMetric(successesDesc, prometheus.CounterValue, float64(counts.TotalSucceses), service)
+		ch <- prometheus.MustNewConstMetric(failuresDesc, prometheus.CounterValue, float64(counts.TotalFailures), service)
+		ch <- prometheus.MustNewConstMetric(rejectsDesc, prometheus.CounterValue, float64(counts.TotalRejects), service)
+		ch <- prometheus.MustNewConstMetric(timeoutsDesc, prometheus.CounterValue, float64(counts.TotalTimeouts), service)
+	}
+
+	c.duration.Collect(ch)
+}
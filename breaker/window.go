@@ -0,0 +1,101 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket holds the request/success/failure counts observed during one
+// slice of the rolling window.
+type bucket struct {
+	requests  uint32
+	successes uint32
+	failures  uint32
+}
+
+// slidingWindow accumulates outcomes into a ring of time-sliced buckets
+// so that trip decisions can be based on a recent window of traffic
+// instead of a single consecutive-failure streak.
+type slidingWindow struct {
+	mu             sync.Mutex
+	bucketDuration time.Duration
+	buckets        []bucket
+	current        int
+	lastRotate     time.Time
+}
+
+func newSlidingWindow(windowDuration time.Duration, bucketCount uint32, now time.Time) *slidingWindow {
+	// A BucketCount large enough relative to WindowDuration truncates
+	// this to 0, which would then divide by zero in rotate. Floor it at
+	// 1ns: the window still slides, just with finer-grained buckets
+	// than the caller asked for.
+	bucketDuration := windowDuration / time.Duration(bucketCount)
+	if bucketDuration <= 0 {
+		bucketDuration = time.Nanosecond
+	}
+
+	return &slidingWindow{
+		bucketDuration: bucketDuration,
+		buckets:        make([]bucket, bucketCount),
+		lastRotate:     now,
+	}
+}
+
+// rotate advances the ring to the bucket that should be active at now,
+// clearing any buckets that have aged out of the window. Callers must
+// hold w.mu.
+func (w *slidingWindow) rotate(now time.Time) {
+	elapsed := now.Sub(w.lastRotate)
+	if elapsed < w.bucketDuration {
+		return
+	}
+
+	total := elapsed / w.bucketDuration
+	steps := int(total)
+	if steps > len(w.buckets) {
+		steps = len(w.buckets)
+	}
+
+	for i := 0; i < steps; i++ {
+		w.current = (w.current + 1) % len(w.buckets)
+		w.buckets[w.current] = bucket{}
+	}
+
+	w.lastRotate = w.lastRotate.Add(total * w.bucketDuration)
+}
+
+// record rotates the window forward to now and then tallies the
+// outcome into the current bucket. A bucket rotation only discards
+// buckets that have aged out of the window; it is not a breaker state
+// transition and must not advance the request generation, or every
+// call still in flight across the rotation boundary would have its
+// outcome silently discarded by afterRequest. Safe for concurrent use.
+func (w *slidingWindow) record(now time.Time, success bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.rotate(now)
+	b := &w.buckets[w.current]
+	b.requests++
+	if success {
+		b.successes++
+	} else {
+		b.failures++
+	}
+}
+
+// snapshot sums every bucket in the window into a single result. Safe
+// for concurrent use.
+func (w *slidingWindow) snapshot(now time.Time) CounterResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.rotate(now)
+	result := CounterResult{}
+	for _, b := range w.buckets {
+		result.TotalRequests += b.requests
+		result.TotalSucceses += b.successes
+		result.TotalFailures += b.failures
+	}
+	return result
+}
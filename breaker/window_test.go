@@ -0,0 +1,63 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+// stepClock is a minimal Clock whose Now() is advanced explicitly by
+// the test, used here only to make window rotation deterministic
+// without pulling in clocktest (which imports this package and would
+// create an import cycle from an in-package test).
+type stepClock struct {
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time                            { return c.now }
+func (c *stepClock) NewTimer(d time.Duration) Timer            { return realClock{}.NewTimer(d) }
+func (c *stepClock) AfterFunc(d time.Duration, f func()) Timer { return realClock{}.AfterFunc(d, f) }
+
+// TestWindowRotationDoesNotBumpGeneration verifies that a sliding
+// window bucket rotation, triggered from Success/Failure, does not
+// advance the breaker's request generation. Only an actual SetState
+// transition should invalidate in-flight requests; otherwise every
+// call still in flight across a rotation boundary would have its
+// outcome silently discarded by afterRequest even though nothing
+// tripped or reset.
+func TestWindowRotationDoesNotBumpGeneration(t *testing.T) {
+	clock := &stepClock{now: time.Unix(0, 0)}
+	cb := NewBreaker(&OptionsConfig{
+		WindowDuration: 10 * time.Millisecond,
+		BucketCount:    2,
+		FailureRatio:   0.5,
+		MinRequests:    1000000, // high enough that this test never trips
+		Clock:          clock,
+	})
+
+	before := cb.currentGeneration()
+
+	clock.now = clock.now.Add(20 * time.Millisecond) // forces rotate() to advance the ring
+	cb.Success()
+
+	after := cb.currentGeneration()
+	if before != after {
+		t.Fatalf("generation changed from %d to %d due to a window bucket rotation", before, after)
+	}
+}
+
+// TestNewSlidingWindowBucketCountLargerThanWindowDuration verifies that
+// a BucketCount large enough to truncate windowDuration/bucketCount to
+// 0 doesn't panic rotate with a divide-by-zero on the first Success/
+// Failure call.
+func TestNewSlidingWindowBucketCountLargerThanWindowDuration(t *testing.T) {
+	now := time.Unix(0, 0)
+	w := newSlidingWindow(100*time.Microsecond, 200000, now)
+
+	w.record(now, true)
+	w.record(now.Add(50*time.Microsecond), false)
+
+	counts := w.snapshot(now.Add(50 * time.Microsecond))
+	if counts.TotalRequests == 0 {
+		t.Fatalf("TotalRequests = 0, want at least the requests recorded within the window")
+	}
+}